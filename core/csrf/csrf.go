@@ -0,0 +1,126 @@
+// Package csrf provides CSRF protection middleware built on top of
+// core/session. It issues a per-session token, exposes it to templates as
+// a "csrfToken" func map entry, and validates it on unsafe HTTP methods.
+//
+// . . .
+//
+//	r.Use(csrf.Middleware)
+//	...
+//	html, _ := tmpl.RenderWithFuncs("form.tmpl", vars, csrf.FuncMap(req))
+//
+// . . .
+package csrf
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"html/template"
+	"net/http"
+
+	"github.com/kamuridesu/gomechan/core/response"
+	"github.com/kamuridesu/gomechan/core/session"
+	"github.com/kamuridesu/gomechan/core/templates"
+)
+
+const (
+	sessionKey = "_csrf_token"
+	headerName = "X-CSRF-Token"
+	formField  = "_csrf"
+)
+
+type contextKey struct{}
+
+// Middleware issues a per-session CSRF token, storing it through
+// core/session, and, for POST/PUT/PATCH/DELETE requests, validates it
+// against the X-CSRF-Token header or _csrf form field. A missing or
+// mismatched token is rejected with 403 through response.ResponseWriter.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		values, err := session.Get(r)
+		if err != nil {
+			values = map[string]any{}
+		}
+
+		token, _ := values[sessionKey].(string)
+		if token == "" {
+			token = generateToken()
+			values[sessionKey] = token
+			_ = session.Save(w, r, values)
+		}
+
+		if isUnsafe(r.Method) {
+			supplied := r.Header.Get(headerName)
+			if supplied == "" {
+				supplied = r.FormValue(formField)
+			}
+			if !validToken(supplied, token) {
+				rw := response.New(&w, r)
+				rw.AsText(http.StatusForbidden, "invalid csrf token")
+				return
+			}
+		}
+
+		ctx := context.WithValue(r.Context(), contextKey{}, token)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// TokenFromContext returns the CSRF token Middleware stored on the
+// request context, or "" if the middleware hasn't run.
+func TokenFromContext(ctx context.Context) string {
+	token, _ := ctx.Value(contextKey{}).(string)
+	return token
+}
+
+// FuncMap returns a template.FuncMap exposing the current request's CSRF
+// token as {{ csrfToken }}. The token is per-session, so register this per
+// request - e.g. via templates.Template.RenderWithFuncs - rather than once
+// on the shared template set.
+func FuncMap(r *http.Request) template.FuncMap {
+	return template.FuncMap{
+		"csrfToken": func() string { return TokenFromContext(r.Context()) },
+	}
+}
+
+// RegisterPlaceholder returns a templates.TemplateOption that registers a
+// no-op "csrfToken" function. Pass it to templates.LoadTemplateFolder so
+// any page calling {{ csrfToken }} parses successfully - html/template
+// requires the function to exist at parse time, before a request (and
+// therefore a real token from FuncMap) exists. The real, per-request token
+// still comes from FuncMap via Template.RenderWithFuncs; this placeholder
+// is only there so the initial parse doesn't fail.
+//
+// Usage:
+//
+//	tmpl, err := templates.LoadTemplateFolder("./templates", csrf.RegisterPlaceholder())
+func RegisterPlaceholder() templates.TemplateOption {
+	return templates.WithFuncMap(template.FuncMap{
+		"csrfToken": func() string { return "" },
+	})
+}
+
+func isUnsafe(method string) bool {
+	switch method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+		return true
+	default:
+		return false
+	}
+}
+
+func validToken(supplied, expected string) bool {
+	if supplied == "" || expected == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(supplied), []byte(expected)) == 1
+}
+
+func generateToken() string {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
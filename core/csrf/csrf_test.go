@@ -0,0 +1,68 @@
+package csrf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kamuridesu/gomechan/core/session"
+)
+
+func TestMiddlewareIssuesAndValidatesToken(t *testing.T) {
+	session.SetKeys(make([]byte, 32))
+	defer session.SetKeys()
+
+	handler := Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	// A safe request issues the session cookie and a CSRF token.
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	cookie := rec.Result().Cookies()[0]
+
+	probe := httptest.NewRequest(http.MethodGet, "/", nil)
+	probe.AddCookie(cookie)
+	values, err := session.Get(probe)
+	if err != nil {
+		t.Fatalf("session.Get: %v", err)
+	}
+	token, _ := values[sessionKey].(string)
+	if token == "" {
+		t.Fatal("expected a csrf token to be issued")
+	}
+
+	// The matching token is accepted on an unsafe method.
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/", nil)
+	req.AddCookie(cookie)
+	req.Header.Set(headerName, token)
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200 with a valid token, got %d", rec.Code)
+	}
+
+	// Replaying a stale token - the one issued to the first session -
+	// against a fresh session is rejected.
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	freshCookie := rec.Result().Cookies()[0]
+
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.AddCookie(freshCookie)
+	req.Header.Set(headerName, token)
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 replaying a stale token, got %d", rec.Code)
+	}
+
+	// No token at all is rejected too.
+	rec = httptest.NewRecorder()
+	req = httptest.NewRequest(http.MethodPost, "/", nil)
+	req.AddCookie(cookie)
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 with no token, got %d", rec.Code)
+	}
+}
@@ -0,0 +1,276 @@
+package response
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/kamuridesu/gomechan/core/templates"
+	"gopkg.in/yaml.v3"
+)
+
+// bufPool recycles the buffers used to encode structured bodies so
+// AsJSON/AsXML don't allocate a fresh buffer on every request.
+var bufPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
+// tmpl is the *templates.Template registered with SetTemplates, used by AsHTML.
+var tmpl *templates.Template
+
+// SetTemplates registers the template set AsHTML renders from.
+func SetTemplates(t *templates.Template) {
+	tmpl = t
+}
+
+// AsJSON encodes v as JSON into a pooled buffer, sets Content-Type and an
+// accurate Content-Length, and sends the response. Unlike AsJson it accepts
+// any value, not just map[string]any.
+//
+// Usage:
+//
+//	responseWriter.AsJSON(http.StatusOK, user)
+func (r *ResponseWriter) AsJSON(status int, v any) error {
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufPool.Put(buf)
+
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	r.headers["content-type"] = "application/json"
+	r.headers["content-length"] = strconv.Itoa(buf.Len())
+	r.Build(status, buf.String())
+	return r.Send()
+}
+
+// AsXML encodes v as XML, sets Content-Type and Content-Length, and sends
+// the response.
+//
+// Usage:
+//
+//	responseWriter.AsXML(http.StatusOK, user)
+func (r *ResponseWriter) AsXML(status int, v any) error {
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufPool.Put(buf)
+
+	if err := xml.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	r.headers["content-type"] = "application/xml"
+	r.headers["content-length"] = strconv.Itoa(buf.Len())
+	r.Build(status, buf.String())
+	return r.Send()
+}
+
+// AsYAML encodes v as YAML, sets Content-Type and Content-Length, and sends
+// the response.
+//
+// Usage:
+//
+//	responseWriter.AsYAML(http.StatusOK, user)
+func (r *ResponseWriter) AsYAML(status int, v any) error {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return err
+	}
+	r.headers["content-type"] = "application/x-yaml"
+	r.headers["content-length"] = strconv.Itoa(len(b))
+	r.Build(status, string(b))
+	return r.Send()
+}
+
+// AsText sends body as plain text.
+//
+// Usage:
+//
+//	responseWriter.AsText(http.StatusOK, "pong")
+func (r *ResponseWriter) AsText(status int, body string) error {
+	r.headers["content-type"] = "text/plain; charset=utf-8"
+	r.Build(status, body)
+	return r.Send()
+}
+
+// AsHTML renders tmplName through the template set registered with
+// SetTemplates and sends the result as text/html.
+//
+// Usage:
+//
+//	responseWriter.AsHTML(http.StatusOK, "index.tmpl", map[string]any{"message": "Test"})
+func (r *ResponseWriter) AsHTML(status int, tmplName string, vars map[string]any) error {
+	if tmpl == nil {
+		return fmt.Errorf("no templates registered, call response.SetTemplates first")
+	}
+	html, err := tmpl.LoadHTML(tmplName, vars)
+	if err != nil {
+		return err
+	}
+	r.headers["content-type"] = "text/html; charset=utf-8"
+	r.Build(status, html)
+	return r.Send()
+}
+
+// Negotiate inspects the request's Accept header and sends whichever of
+// offers best matches it, honoring q-weighting, e.g.:
+//
+//	responseWriter.Negotiate(http.StatusOK, map[string]any{
+//		"application/json": user,
+//		"application/xml":  user,
+//		"text/html":        "user.tmpl",
+//	})
+//
+// A "text/html" offer is rendered through AsHTML and must be a template
+// name. Every other media type is encoded with the matching As* method.
+// Replies with 406 if none of the offered media types are acceptable.
+func (r *ResponseWriter) Negotiate(status int, offers map[string]any) error {
+	mediaType, v, ok := bestOffer(parseAccept(r.r.Header.Get("Accept")), offers)
+	if !ok {
+		return r.AsText(http.StatusNotAcceptable, "406 not acceptable")
+	}
+	switch mediaType {
+	case "application/json":
+		return r.AsJSON(status, v)
+	case "application/xml":
+		return r.AsXML(status, v)
+	case "application/x-yaml", "application/yaml", "text/yaml":
+		return r.AsYAML(status, v)
+	case "text/html":
+		name, _ := v.(string)
+		return r.AsHTML(status, name, nil)
+	default:
+		text, _ := v.(string)
+		return r.AsText(status, text)
+	}
+}
+
+type acceptedType struct {
+	mediaType string
+	q         float64
+}
+
+// parseAccept parses an Accept header into media types ordered by
+// descending q-weight, defaulting to 1 when a type carries no q param.
+func parseAccept(header string) []acceptedType {
+	if header == "" {
+		return []acceptedType{{mediaType: "*/*", q: 1}}
+	}
+	parts := strings.Split(header, ",")
+	accepted := make([]acceptedType, 0, len(parts))
+	for _, part := range parts {
+		part = strings.TrimSpace(part)
+		mediaType, params, _ := strings.Cut(part, ";")
+		q := 1.0
+		for _, param := range strings.Split(params, ";") {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		accepted = append(accepted, acceptedType{mediaType: strings.TrimSpace(mediaType), q: q})
+	}
+	sort.SliceStable(accepted, func(i, j int) bool { return accepted[i].q > accepted[j].q })
+	return accepted
+}
+
+// bestOffer returns the first offer acceptable by accepted, in preference order.
+func bestOffer(accepted []acceptedType, offers map[string]any) (string, any, bool) {
+	for _, a := range accepted {
+		if a.q <= 0 {
+			continue
+		}
+		if a.mediaType == "*/*" {
+			for mediaType, v := range offers {
+				return mediaType, v, true
+			}
+		}
+		if v, ok := offers[a.mediaType]; ok {
+			return a.mediaType, v, true
+		}
+		if prefix, ok := strings.CutSuffix(a.mediaType, "/*"); ok {
+			for mediaType, v := range offers {
+				if strings.HasPrefix(mediaType, prefix+"/") {
+					return mediaType, v, true
+				}
+			}
+		}
+	}
+	return "", nil, false
+}
+
+// AsStream copies src to the client as it's read, flushing after every
+// chunk so large or slow-to-produce bodies don't have to be buffered into
+// ResponseWriter.body first.
+//
+// Usage:
+//
+//	responseWriter.AsStream(http.StatusOK, file)
+func (r *ResponseWriter) AsStream(status int, src io.Reader) error {
+	for k, v := range r.headers {
+		(*r.w).Header().Add(k, v)
+	}
+	(*r.w).WriteHeader(status)
+	r.status = status
+
+	flusher, canFlush := (*r.w).(http.Flusher)
+	buf := make([]byte, 32*1024)
+	for {
+		n, readErr := src.Read(buf)
+		if n > 0 {
+			if _, err := (*r.w).Write(buf[:n]); err != nil {
+				return err
+			}
+			if canFlush {
+				flusher.Flush()
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return readErr
+		}
+	}
+	return r.logIfNeeded()
+}
+
+// AsSSE prepares the response as a text/event-stream and returns a send
+// function the caller uses to push further events, flushing each one
+// immediately.
+//
+// Usage:
+//
+//	send, err := responseWriter.AsSSE()
+//	send("ping")
+func (r *ResponseWriter) AsSSE() (send func(event string) error, err error) {
+	flusher, ok := (*r.w).(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("response writer does not support flushing")
+	}
+
+	r.headers["content-type"] = "text/event-stream"
+	r.headers["cache-control"] = "no-cache"
+	r.headers["connection"] = "keep-alive"
+	for k, v := range r.headers {
+		(*r.w).Header().Add(k, v)
+	}
+	(*r.w).WriteHeader(http.StatusOK)
+	r.status = http.StatusOK
+	flusher.Flush()
+
+	return func(event string) error {
+		if _, err := io.WriteString((*r.w), "data: "+event+"\n\n"); err != nil {
+			return err
+		}
+		flusher.Flush()
+		return nil
+	}, nil
+}
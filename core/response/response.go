@@ -19,7 +19,6 @@ package response
 
 import (
 	"encoding/json"
-	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -27,6 +26,38 @@ import (
 	"time"
 )
 
+// logger is the *slog.Logger used for access logs, overridable with SetLogger.
+var logger = slog.Default()
+
+// SetLogger overrides the *slog.Logger used for access logs, e.g. to plug
+// in a JSON handler or to attach request-scoped attributes.
+func SetLogger(l *slog.Logger) {
+	logger = l
+}
+
+// byteCountingWriter wraps an http.ResponseWriter to track how many bytes
+// were actually written to the client, since ResponseWriter.body alone
+// doesn't account for writers that stream straight to the underlying
+// connection.
+type byteCountingWriter struct {
+	http.ResponseWriter
+	bytes int64
+}
+
+func (w *byteCountingWriter) Write(b []byte) (int, error) {
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Flush forwards to the wrapped writer's http.Flusher, if it has one, so
+// wrapping in byteCountingWriter doesn't break streaming responses.
+func (w *byteCountingWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
 // ResponseWriter to manage response to be sent with http.ResponseWriter, logging information of http.Request.
 // It keeps track of how long a request took to complete, the path, method, etc using log/slog to show output.
 type ResponseWriter struct {
@@ -36,18 +67,22 @@ type ResponseWriter struct {
 	start     time.Time
 	ignoreLog bool
 	w         *http.ResponseWriter
+	counter   *byteCountingWriter
 	r         *http.Request
 }
 
 // Creates new ResponseWriter to manage Responses.
 func New(w *http.ResponseWriter, r *http.Request) ResponseWriter {
+	counter := &byteCountingWriter{ResponseWriter: *w}
+	var counted http.ResponseWriter = counter
 	return ResponseWriter{
 		start:     time.Now(),
 		ignoreLog: false,
 		status:    http.StatusOK,
 		headers:   map[string]string{},
 		body:      "",
-		w:         w,
+		w:         &counted,
+		counter:   counter,
 		r:         r,
 	}
 }
@@ -82,11 +117,24 @@ func (r *ResponseWriter) Send() error {
 	if err != nil {
 		return err
 	}
-	requestTime := time.Since(r.start)
-	if !r.ignoreLog {
-		slog.Info(fmt.Sprintf("| %-3d | %-30v | %-15s | %-6s | %-30s",
-			r.status, requestTime, strings.Split(r.r.RemoteAddr, ":")[0], r.r.Method, r.r.URL))
+	return r.logIfNeeded()
+}
+
+// logIfNeeded emits the access log line unless IgnoreLog was called. It's
+// shared by Send and AsStream in render.go, which writes directly to the
+// underlying writer instead of going through Send. AsSSE does not log.
+func (r *ResponseWriter) logIfNeeded() error {
+	if r.ignoreLog {
+		return nil
 	}
+	logger.Info("request",
+		slog.Int("status", r.status),
+		slog.Duration("elapsed", time.Since(r.start)),
+		slog.String("method", r.r.Method),
+		slog.String("path", r.r.URL.Path),
+		slog.String("remote", strings.Split(r.r.RemoteAddr, ":")[0]),
+		slog.Int64("bytes", r.counter.bytes),
+	)
 	return nil
 }
 
@@ -105,8 +153,7 @@ func (r *ResponseWriter) AsJson(status int, body map[string]any) error {
 	}
 	r.headers["content-type"] = "application/json"
 	r.Build(status, string(b))
-	r.Send()
-	return nil
+	return r.Send()
 }
 
 // Don't log request, useful for static files or health checks
@@ -0,0 +1,201 @@
+package router
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// requestIDKey is the context key under which RequestID stores the
+// generated ID.
+type requestIDKey struct{}
+
+// Recovery returns middleware that recovers from panics in the handler
+// chain, logs them with log/slog and replies with a 500 instead of
+// crashing the server.
+func Recovery() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer func() {
+				if rec := recover(); rec != nil {
+					slog.Error("panic recovered", slog.Any("error", rec), slog.String("path", r.URL.Path))
+					http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				}
+			}()
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequestID returns middleware that assigns each request an ID - the
+// incoming X-Request-ID header if the client sent one, otherwise a random
+// hex-encoded ID - exposes it on the request context (retrieve it with
+// RequestIDFromContext) and echoes it back as the X-Request-ID header.
+func RequestID() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := r.Header.Get("X-Request-ID")
+			if id == "" {
+				id = newRequestID()
+			}
+			w.Header().Set("X-Request-ID", id)
+			ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequestIDFromContext returns the request ID set by RequestID, or "" if
+// the middleware was not used.
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(buf)
+}
+
+// CORSConfig controls the headers CORS adds to a response. A zero-value
+// CORSConfig allows any origin with GET, POST, PUT, PATCH and DELETE.
+type CORSConfig struct {
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+}
+
+func (c CORSConfig) withDefaults() CORSConfig {
+	if len(c.AllowedOrigins) == 0 {
+		c.AllowedOrigins = []string{"*"}
+	}
+	if len(c.AllowedMethods) == 0 {
+		c.AllowedMethods = []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete}
+	}
+	if len(c.AllowedHeaders) == 0 {
+		c.AllowedHeaders = []string{"Content-Type", "Authorization"}
+	}
+	return c
+}
+
+// CORS returns middleware that sets Access-Control-* headers according to
+// cfg and short-circuits preflight OPTIONS requests with a 204.
+//
+// Access-Control-Allow-Origin may only ever carry a single origin or "*" -
+// a comma-joined list of allowed origins is invalid per the Fetch/CORS
+// spec and rejected by every browser. So when AllowedOrigins holds more
+// than one explicit origin, the request's own Origin header is reflected
+// back if it's on the list (with Vary: Origin, since the response then
+// differs by request), rather than sending the whole list at once.
+func CORS(cfg CORSConfig) Middleware {
+	cfg = cfg.withDefaults()
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	allowAny := len(cfg.AllowedOrigins) == 1 && cfg.AllowedOrigins[0] == "*"
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case allowAny:
+				w.Header().Set("Access-Control-Allow-Origin", "*")
+			case isAllowedOrigin(cfg.AllowedOrigins, r.Header.Get("Origin")):
+				w.Header().Set("Access-Control-Allow-Origin", r.Header.Get("Origin"))
+				w.Header().Add("Vary", "Origin")
+			}
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			w.Header().Set("Access-Control-Allow-Headers", headers)
+			if r.Method == http.MethodOptions {
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isAllowedOrigin(allowed []string, origin string) bool {
+	if origin == "" {
+		return false
+	}
+	for _, o := range allowed {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
+
+// Gzip returns middleware that transparently gzip-compresses the response
+// body when the client sends "Accept-Encoding: gzip". It leaves the body
+// alone if the handler already set Content-Encoding itself - e.g.
+// routes.AddStatic serving a precompressed .gz asset - since compressing
+// that on top would double-encode it while the header still claims a
+// single encoding.
+func Gzip() Middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if !strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+				next.ServeHTTP(w, r)
+				return
+			}
+			grw := &gzipResponseWriter{ResponseWriter: w}
+			defer func() {
+				if grw.writer != nil {
+					grw.writer.Close()
+				}
+			}()
+			next.ServeHTTP(grw, r)
+		})
+	}
+}
+
+// gzipResponseWriter makes a gzip.Writer satisfy http.ResponseWriter,
+// writing the body through it while delegating headers and status codes to
+// the wrapped ResponseWriter. Whether it actually compresses is decided
+// lazily in WriteHeader, once the handler has had a chance to set its own
+// Content-Encoding.
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	writer      *gzip.Writer
+	wroteHeader bool
+	passthrough bool
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	return w.writer.Write(b)
+}
+
+// WriteHeader decides, based on whether the handler already set
+// Content-Encoding, whether to compress: if so, the body is left as-is and
+// passed through untouched; otherwise it strips any Content-Length set by
+// the handler, since that described the uncompressed body and the gzip
+// stream's size isn't known until writing finishes.
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.wroteHeader {
+		return
+	}
+	w.wroteHeader = true
+	if w.Header().Get("Content-Encoding") != "" {
+		w.passthrough = true
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Add("Vary", "Accept-Encoding")
+	w.Header().Del("Content-Length")
+	w.writer = gzip.NewWriter(w.ResponseWriter)
+	w.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,155 @@
+package router
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecoveryTurnsPanicInto500(t *testing.T) {
+	r := New()
+	r.Use(Recovery())
+	r.GET("/boom", func(w http.ResponseWriter, req *http.Request) { panic("boom") })
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/boom", nil))
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("got %d, want 500", rec.Code)
+	}
+}
+
+func TestRequestIDIsGeneratedAndEchoed(t *testing.T) {
+	r := New()
+	r.Use(RequestID())
+	var seen string
+	r.GET("/ping", func(w http.ResponseWriter, req *http.Request) {
+		seen = RequestIDFromContext(req.Context())
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	if seen == "" {
+		t.Fatal("expected a request ID on the context")
+	}
+	if rec.Header().Get("X-Request-ID") != seen {
+		t.Errorf("X-Request-ID header = %q, want %q", rec.Header().Get("X-Request-ID"), seen)
+	}
+}
+
+func TestRequestIDHonorsIncomingHeader(t *testing.T) {
+	r := New()
+	r.Use(RequestID())
+	r.GET("/ping", func(w http.ResponseWriter, req *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("X-Request-ID", "fixed-id")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if got := rec.Header().Get("X-Request-ID"); got != "fixed-id" {
+		t.Errorf("X-Request-ID = %q, want %q", got, "fixed-id")
+	}
+}
+
+func TestCORSReflectsAllowedOriginOnly(t *testing.T) {
+	r := New()
+	r.Use(CORS(CORSConfig{AllowedOrigins: []string{"https://foo.com", "https://bar.com"}}))
+	r.GET("/ping", func(w http.ResponseWriter, req *http.Request) {})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Origin", "https://bar.com")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "https://bar.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, "https://bar.com")
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Origin", "https://evil.com")
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if got := rec.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want empty for a disallowed origin", got)
+	}
+}
+
+func TestCORSPreflightShortCircuits(t *testing.T) {
+	r := New()
+	r.Use(CORS(CORSConfig{}))
+	called := false
+	r.HandleFunc("/ping", func(w http.ResponseWriter, req *http.Request) { called = true })
+
+	req := httptest.NewRequest(http.MethodOptions, "/ping", nil)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNoContent {
+		t.Errorf("got %d, want 204", rec.Code)
+	}
+	if called {
+		t.Error("handler should not run for a preflight OPTIONS request")
+	}
+}
+
+func TestGzipCompressesWhenAccepted(t *testing.T) {
+	r := New()
+	r.Use(Gzip())
+	r.GET("/ping", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Length", "999")
+		w.Write([]byte("hello world"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/ping", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	if rec.Header().Get("Content-Length") != "" {
+		t.Error("stale Content-Length should have been stripped")
+	}
+	gr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("response body is not valid gzip: %s", err)
+	}
+	body, err := io.ReadAll(gr)
+	if err != nil || string(body) != "hello world" {
+		t.Errorf("got %q, %v, want %q", body, err, "hello world")
+	}
+}
+
+func TestGzipSkipsHandlerThatAlreadyEncoded(t *testing.T) {
+	r := New()
+	r.Use(Gzip())
+	const raw = "already-encoded-body"
+	r.GET("/file.gz", func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.Write([]byte(raw))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/file.gz", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if got := rec.Body.String(); got != raw {
+		t.Errorf("body was compressed a second time: got %q, want untouched %q", got, raw)
+	}
+}
+
+func TestGzipSkippedWithoutAcceptEncoding(t *testing.T) {
+	r := New()
+	r.Use(Gzip())
+	r.GET("/ping", func(w http.ResponseWriter, req *http.Request) { w.Write([]byte("hello")) })
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ping", nil))
+	if rec.Header().Get("Content-Encoding") != "" {
+		t.Error("should not set Content-Encoding when the client doesn't accept gzip")
+	}
+	if rec.Body.String() != "hello" {
+		t.Errorf("got %q", rec.Body.String())
+	}
+}
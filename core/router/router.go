@@ -0,0 +1,192 @@
+// Package router wraps http.ServeMux to provide gin-style ergonomics on top
+// of the standard library: method-scoped registration, route groups with
+// inherited middleware, and gin-style path parameters (/user/:name).
+//
+// . . .
+//
+//	r := router.New()
+//	r.Use(router.Recovery(), router.RequestID())
+//	r.GET("/user/:name", func(w http.ResponseWriter, req *http.Request) {
+//		name := router.Param(req, "name")
+//		...
+//	})
+//	api := r.Group("/api")
+//	api.GET("/ping", pingHandler)
+//	http.ListenAndServe(":8080", r)
+//
+// . . .
+package router
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// Middleware wraps an http.Handler to add behavior before and/or after it runs.
+type Middleware func(http.Handler) http.Handler
+
+// route is a single registered pattern, matched manually so it can support
+// gin-style ":name" segments regardless of the Go version's http.ServeMux.
+// A pattern ending in "/" is a subtree match, the same convention
+// http.ServeMux uses, so a directory tree (e.g. static files) can be
+// registered once under its prefix.
+type route struct {
+	method   string
+	segments []string
+	subtree  bool
+	handler  http.Handler
+}
+
+// Router wraps http.ServeMux, adding method-scoped registration, grouping
+// and a middleware chain. The zero value is not usable, use New.
+type Router struct {
+	routes     *[]route
+	fallback   *http.ServeMux
+	prefix     string
+	middleware []Middleware
+}
+
+// New creates a Router ready to register routes on.
+func New() *Router {
+	return &Router{
+		routes:   &[]route{},
+		fallback: http.NewServeMux(),
+	}
+}
+
+// Use appends middleware to the chain applied to every route registered
+// on this Router from this point on, and to Groups derived from it
+// afterwards. A Group created before this call keeps only the middleware
+// it inherited when it was created.
+func (rt *Router) Use(middleware ...Middleware) {
+	rt.middleware = append(rt.middleware, middleware...)
+}
+
+// Group returns a new Router scoped to prefix, sharing the same underlying
+// route table and inheriting the middleware registered on rt so far.
+// Middleware added to the group afterwards does not affect rt or sibling
+// groups.
+func (rt *Router) Group(prefix string) *Router {
+	return &Router{
+		routes:     rt.routes,
+		fallback:   rt.fallback,
+		prefix:     rt.prefix + prefix,
+		middleware: append([]Middleware{}, rt.middleware...),
+	}
+}
+
+// GET registers handler for GET requests matching pattern.
+func (rt *Router) GET(pattern string, handler http.HandlerFunc) {
+	rt.handle(http.MethodGet, pattern, handler)
+}
+
+// POST registers handler for POST requests matching pattern.
+func (rt *Router) POST(pattern string, handler http.HandlerFunc) {
+	rt.handle(http.MethodPost, pattern, handler)
+}
+
+// PUT registers handler for PUT requests matching pattern.
+func (rt *Router) PUT(pattern string, handler http.HandlerFunc) {
+	rt.handle(http.MethodPut, pattern, handler)
+}
+
+// DELETE registers handler for DELETE requests matching pattern.
+func (rt *Router) DELETE(pattern string, handler http.HandlerFunc) {
+	rt.handle(http.MethodDelete, pattern, handler)
+}
+
+// PATCH registers handler for PATCH requests matching pattern.
+func (rt *Router) PATCH(pattern string, handler http.HandlerFunc) {
+	rt.handle(http.MethodPatch, pattern, handler)
+}
+
+// HandleFunc registers handler for pattern regardless of method. It exists
+// so a *Router can be passed anywhere the legacy router interface used by
+// package routes (a single HandleFunc method) is expected.
+func (rt *Router) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	rt.handle("", pattern, http.HandlerFunc(handler))
+}
+
+// ServeHTTP lets Router be used directly with http.ListenAndServe.
+func (rt *Router) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	requestSegments := splitPath(r.URL.Path)
+	for _, rte := range *rt.routes {
+		if rte.method != "" && rte.method != r.Method {
+			continue
+		}
+		if params, ok := matchRoute(rte, requestSegments); ok {
+			ctx := r.Context()
+			if len(params) > 0 {
+				ctx = context.WithValue(ctx, paramsKey{}, params)
+			}
+			rte.handler.ServeHTTP(w, r.WithContext(ctx))
+			return
+		}
+	}
+	rt.fallback.ServeHTTP(w, r)
+}
+
+// Param returns the value of the named path parameter registered with a
+// gin-style pattern such as /user/:name.
+func Param(r *http.Request, name string) string {
+	params, _ := r.Context().Value(paramsKey{}).(map[string]string)
+	return params[name]
+}
+
+type paramsKey struct{}
+
+func (rt *Router) handle(method, pattern string, handler http.Handler) {
+	for i := len(rt.middleware) - 1; i >= 0; i-- {
+		handler = rt.middleware[i](handler)
+	}
+	full := rt.prefix + pattern
+	*rt.routes = append(*rt.routes, route{
+		method:   method,
+		segments: splitPath(full),
+		subtree:  strings.HasSuffix(full, "/"),
+		handler:  handler,
+	})
+}
+
+func splitPath(p string) []string {
+	p = strings.Trim(p, "/")
+	if p == "" {
+		return []string{}
+	}
+	return strings.Split(p, "/")
+}
+
+// matchRoute compares a registered route's segments against the segments
+// of an incoming request path, collecting any ":name" parameters along the
+// way. A subtree route only needs to match its own segments as a prefix;
+// everything past that belongs to the matched file or sub-resource.
+func matchRoute(rte route, requestSegments []string) (map[string]string, bool) {
+	if rte.subtree {
+		if len(requestSegments) < len(rte.segments) {
+			return nil, false
+		}
+		return matchSegments(rte.segments, requestSegments[:len(rte.segments)])
+	}
+	if len(requestSegments) != len(rte.segments) {
+		return nil, false
+	}
+	return matchSegments(rte.segments, requestSegments)
+}
+
+func matchSegments(routeSegments, requestSegments []string) (map[string]string, bool) {
+	var params map[string]string
+	for i, seg := range routeSegments {
+		if strings.HasPrefix(seg, ":") {
+			if params == nil {
+				params = map[string]string{}
+			}
+			params[seg[1:]] = requestSegments[i]
+			continue
+		}
+		if seg != requestSegments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
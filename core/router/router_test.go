@@ -0,0 +1,114 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMethodsMatchOnlyTheirOwnMethod(t *testing.T) {
+	r := New()
+	r.GET("/ping", func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusOK) })
+	r.POST("/ping", func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusCreated) })
+
+	cases := []struct {
+		method string
+		want   int
+	}{
+		{http.MethodGet, http.StatusOK},
+		{http.MethodPost, http.StatusCreated},
+		{http.MethodPut, http.StatusNotFound},
+	}
+	for _, c := range cases {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(c.method, "/ping", nil))
+		if rec.Code != c.want {
+			t.Errorf("%s /ping: got %d, want %d", c.method, rec.Code, c.want)
+		}
+	}
+}
+
+func TestPathParam(t *testing.T) {
+	r := New()
+	r.GET("/user/:name", func(w http.ResponseWriter, req *http.Request) {
+		w.Write([]byte(Param(req, "name")))
+	})
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/user/alice", nil))
+	if got := rec.Body.String(); got != "alice" {
+		t.Errorf("got %q, want %q", got, "alice")
+	}
+}
+
+func TestGroupPrefixAndInheritedMiddleware(t *testing.T) {
+	r := New()
+	var order []string
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			order = append(order, "outer")
+			next.ServeHTTP(w, req)
+		})
+	})
+
+	api := r.Group("/api")
+	api.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			order = append(order, "inner")
+			next.ServeHTTP(w, req)
+		})
+	})
+	api.GET("/ping", func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	// A route registered directly on r, after the Group call, must not pick
+	// up the group's own middleware.
+	r.GET("/outside", func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/ping", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/api/ping: got %d", rec.Code)
+	}
+	if want := []string{"outer", "inner"}; !equal(order, want) {
+		t.Errorf("middleware order = %v, want %v", order, want)
+	}
+
+	order = nil
+	rec = httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/outside", nil))
+	if want := []string{"outer"}; !equal(order, want) {
+		t.Errorf("middleware order = %v, want %v", order, want)
+	}
+}
+
+func TestSubtreeMatchesAnythingUnderPrefix(t *testing.T) {
+	r := New()
+	r.HandleFunc("/static/", func(w http.ResponseWriter, req *http.Request) { w.WriteHeader(http.StatusOK) })
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/css/app.css", nil))
+	if rec.Code != http.StatusOK {
+		t.Errorf("/static/css/app.css: got %d", rec.Code)
+	}
+}
+
+func TestUnmatchedRouteFallsThroughTo404(t *testing.T) {
+	r := New()
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/nope", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("got %d, want 404", rec.Code)
+	}
+}
+
+func equal(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"expvar"
+	"net/http"
+	"net/http/pprof"
+)
+
+// Add debug endpoints to the router: net/http/pprof under /debug/pprof/ and
+// the expvar dump at /debug/vars. These expose internal process state, so
+// enabled must be wired to an explicit config flag and left off in
+// production.
+//
+// Usage:
+//
+//	routes.AddDebug(r, cfg.Debug)
+func AddDebug(r router, enabled bool) {
+	if !enabled {
+		return
+	}
+	// The router matches routes in registration order and the subtree
+	// pattern "/debug/pprof/" matches any path under it, so the exact
+	// paths must be registered first or they'd never be reached.
+	r.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	r.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	r.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	r.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	r.HandleFunc("/debug/pprof/", pprof.Index)
+	r.HandleFunc("/debug/vars", func(w http.ResponseWriter, req *http.Request) {
+		expvar.Handler().ServeHTTP(w, req)
+	})
+}
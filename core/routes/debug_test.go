@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	coreRouter "github.com/kamuridesu/gomechan/core/router"
+)
+
+// A regression test for a routing-order bug: the exact /debug/pprof/*
+// paths must win over the "/debug/pprof/" subtree route, or they all fall
+// through to pprof.Index instead of their own handler.
+func TestAddDebugExactPathsWinOverSubtree(t *testing.T) {
+	r := coreRouter.New()
+	AddDebug(r, true)
+
+	// profile/trace default to long-running collection windows when no
+	// "seconds" query param is given (30s and 1s respectively); pin them to
+	// the shortest accepted value so the test doesn't hang.
+	paths := []string{
+		"/debug/pprof/cmdline",
+		"/debug/pprof/profile?seconds=1",
+		"/debug/pprof/symbol",
+		"/debug/pprof/trace?seconds=0.01",
+	}
+	for _, path := range paths {
+		rec := httptest.NewRecorder()
+		r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		if rec.Code == http.StatusNotFound {
+			t.Errorf("%s: got 404, exact route was shadowed by the /debug/pprof/ subtree route", path)
+		}
+	}
+}
+
+func TestAddDebugDisabled(t *testing.T) {
+	r := coreRouter.New()
+	AddDebug(r, false)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected /debug/pprof/ to be unregistered when disabled, got %d", rec.Code)
+	}
+}
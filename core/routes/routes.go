@@ -8,8 +8,19 @@ type router interface {
 	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
 }
 
+// methodRouter is implemented by routers that support method-scoped
+// registration, such as *core/router.Router. AddHealthCheck prefers this
+// so the health check is only ever registered for GET.
+type methodRouter interface {
+	GET(pattern string, handler http.HandlerFunc)
+}
+
 // Add a health check route to the router
 // It can be consulted by requesting the /health path
 func AddHealthCheck(r router) {
+	if mr, ok := r.(methodRouter); ok {
+		mr.GET("/health", HealthCheck)
+		return
+	}
 	r.HandleFunc("/health", HealthCheck)
 }
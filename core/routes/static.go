@@ -0,0 +1,155 @@
+package routes
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/kamuridesu/gomechan/core/response"
+)
+
+// StaticOption configures AddStatic.
+type StaticOption func(*staticConfig)
+
+type staticConfig struct {
+	hashETag bool
+}
+
+// WithHashedETag computes each file's ETag from a SHA-256 of its content,
+// taken the first time the file is served and cached until its mtime or
+// size changes. The default is cheaper - an ETag derived from mtime+size -
+// but changes if a deploy doesn't preserve mtimes.
+func WithHashedETag() StaticOption {
+	return func(c *staticConfig) { c.hashETag = true }
+}
+
+type cachedETag struct {
+	etag    string
+	modTime int64
+	size    int64
+}
+
+// AddStatic serves the directory tree rooted at dir under urlPrefix, going
+// beyond http.FileServer: it sets a strong ETag and honors
+// If-None-Match/If-Modified-Since with 304s, supports Range requests for
+// large assets via http.ServeContent, and - when a sibling "name.gz" or
+// "name.br" exists and the client's Accept-Encoding accepts it - serves
+// the precompressed variant with the matching Content-Encoding.
+//
+// Usage:
+//
+//	routes.AddStatic(r, "/static/", "./public")
+func AddStatic(r router, urlPrefix, dir string, opts ...StaticOption) {
+	cfg := &staticConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	root := filepath.Clean(dir)
+	etags := &sync.Map{} // file path joined onto root -> *cachedETag
+
+	r.HandleFunc(urlPrefix, func(w http.ResponseWriter, req *http.Request) {
+		rw := response.New(&w, req)
+
+		rel := strings.TrimPrefix(req.URL.Path, urlPrefix)
+		name := filepath.Join(root, filepath.FromSlash(rel))
+		if !withinRoot(root, name) {
+			rw.Build(http.StatusNotFound, "404 page not found").Send()
+			return
+		}
+
+		info, err := os.Stat(name)
+		if err != nil || info.IsDir() {
+			rw.Build(http.StatusNotFound, "404 page not found").Send()
+			return
+		}
+
+		servePath := name
+		if alt, encoding, ok := precompressed(name, req.Header.Get("Accept-Encoding")); ok {
+			servePath = alt
+			w.Header().Set("Content-Encoding", encoding)
+			w.Header().Add("Vary", "Accept-Encoding")
+		}
+
+		file, err := os.Open(servePath)
+		if err != nil {
+			rw.Build(http.StatusNotFound, "404 page not found").Send()
+			return
+		}
+		defer file.Close()
+
+		w.Header().Set("ETag", etagFor(etags, name, info, cfg.hashETag))
+
+		// The request is served directly through http.ServeContent rather
+		// than ResponseWriter.Send, so it never hits the access log; mark
+		// it explicitly so that stays true if that ever changes.
+		rw.IgnoreLog()
+		http.ServeContent(w, req, name, info.ModTime(), file)
+	})
+}
+
+func withinRoot(root, name string) bool {
+	return name == root || strings.HasPrefix(name, root+string(os.PathSeparator))
+}
+
+// precompressed looks for a .br or .gz sibling of name and returns it if
+// the client's Accept-Encoding header accepts that encoding, preferring
+// br over gzip when both are available.
+func precompressed(name, acceptEncoding string) (path string, encoding string, ok bool) {
+	candidates := []struct{ suffix, encoding string }{
+		{".br", "br"},
+		{".gz", "gzip"},
+	}
+	for _, c := range candidates {
+		if !strings.Contains(acceptEncoding, c.encoding) {
+			continue
+		}
+		alt := name + c.suffix
+		if stat, err := os.Stat(alt); err == nil && !stat.IsDir() {
+			return alt, c.encoding, true
+		}
+	}
+	return "", "", false
+}
+
+// etagFor returns a strong ETag for name, either derived from its
+// mtime+size or, with WithHashedETag, a hash of its contents computed once
+// and cached until the file changes.
+func etagFor(cache *sync.Map, name string, info os.FileInfo, hashed bool) string {
+	if !hashed {
+		return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+	}
+
+	if cached, ok := cache.Load(name); ok {
+		entry := cached.(*cachedETag)
+		if entry.modTime == info.ModTime().UnixNano() && entry.size == info.Size() {
+			return entry.etag
+		}
+	}
+
+	etag, err := hashFile(name)
+	if err != nil {
+		return fmt.Sprintf(`"%x-%x"`, info.ModTime().UnixNano(), info.Size())
+	}
+	cache.Store(name, &cachedETag{etag: etag, modTime: info.ModTime().UnixNano(), size: info.Size()})
+	return etag
+}
+
+func hashFile(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(`"%x"`, h.Sum(nil)), nil
+}
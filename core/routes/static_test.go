@@ -0,0 +1,93 @@
+package routes
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	coreRouter "github.com/kamuridesu/gomechan/core/router"
+)
+
+func TestAddStaticServesPrecompressedGzipVariant(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte("uncompressed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	gz, err := os.Create(filepath.Join(dir, "app.js.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := gzip.NewWriter(gz)
+	gw.Write([]byte("compressed"))
+	gw.Close()
+	gz.Close()
+
+	r := coreRouter.New()
+	AddStatic(r, "/static/", dir)
+
+	req := httptest.NewRequest(http.MethodGet, "/static/app.js", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	if got := rec.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	gr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("response body is not valid gzip: %s", err)
+	}
+	body, err := io.ReadAll(gr)
+	if err != nil || string(body) != "compressed" {
+		t.Errorf("got %q, %v, want the precompressed file's content", body, err)
+	}
+}
+
+func TestAddStaticUnderGzipMiddlewareIsNotDoubleCompressed(t *testing.T) {
+	dir := t.TempDir()
+	gz, err := os.Create(filepath.Join(dir, "app.js.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	gw := gzip.NewWriter(gz)
+	gw.Write([]byte("compressed"))
+	gw.Close()
+	gz.Close()
+	if err := os.WriteFile(filepath.Join(dir, "app.js"), []byte("uncompressed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r := coreRouter.New()
+	r.Use(coreRouter.Gzip())
+	AddStatic(r, "/static/", dir)
+
+	req := httptest.NewRequest(http.MethodGet, "/static/app.js", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	gr, err := gzip.NewReader(rec.Body)
+	if err != nil {
+		t.Fatalf("response body should decode as a single gzip layer, not double-compressed: %s", err)
+	}
+	body, err := io.ReadAll(gr)
+	if err != nil || string(body) != "compressed" {
+		t.Errorf("got %q, %v, want %q", body, err, "compressed")
+	}
+}
+
+func TestAddStaticNotFoundOutsideRoot(t *testing.T) {
+	dir := t.TempDir()
+	r := coreRouter.New()
+	AddStatic(r, "/static/", dir)
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/static/../secret", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("got %d, want 404 for a path escaping the static root", rec.Code)
+	}
+}
@@ -0,0 +1,150 @@
+// Package session provides an encrypted-cookie session store. Values are
+// gob-encoded and sealed with AES-GCM before being placed in a cookie, so
+// the server stays stateless while the client can't read or tamper with
+// the contents.
+//
+// . . .
+//
+//	session.SetKeys(currentKey, previousKey)
+//	values, _ := session.Get(r)
+//	values["user"] = "alice"
+//	session.Save(w, r, values)
+//
+// . . .
+package session
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/gob"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+const cookieName = "gomechan_session"
+
+var (
+	mu   sync.RWMutex
+	keys [][]byte
+)
+
+// SetKeys configures the AES-GCM keys used to seal and open session
+// cookies. The first key seals new cookies; every key is tried, in order,
+// when opening one, so rotating keys is as simple as prepending the new
+// key and dropping the oldest once you're confident no live cookie still
+// needs it. Each key must be 16, 24 or 32 bytes long (AES-128/192/256).
+func SetKeys(newKeys ...[]byte) {
+	mu.Lock()
+	defer mu.Unlock()
+	keys = newKeys
+}
+
+// Register makes a concrete type usable as a session value. Session
+// values are carried in a map[string]any and gob-encoded, so any type
+// other than the predeclared ones must be registered once, typically at
+// startup, before it's stored with Save.
+func Register(zero any) {
+	gob.Register(zero)
+}
+
+// Get decrypts and decodes the session cookie on r. It returns an empty,
+// non-nil map if there's no cookie yet, or if the cookie can't be opened
+// with any configured key (e.g. it was sealed with a key that has since
+// been rotated out) - a missing session is not an error to callers.
+func Get(r *http.Request) (map[string]any, error) {
+	empty := map[string]any{}
+
+	cookie, err := r.Cookie(cookieName)
+	if err != nil {
+		return empty, nil
+	}
+
+	ciphertext, err := base64.URLEncoding.DecodeString(cookie.Value)
+	if err != nil {
+		return empty, nil
+	}
+
+	mu.RLock()
+	defer mu.RUnlock()
+	for _, key := range keys {
+		plaintext, err := open(key, ciphertext)
+		if err != nil {
+			continue
+		}
+		values := map[string]any{}
+		if err := gob.NewDecoder(bytes.NewReader(plaintext)).Decode(&values); err != nil {
+			return empty, nil
+		}
+		return values, nil
+	}
+	return empty, nil
+}
+
+// Save gob-encodes values, seals them with the newest configured key and
+// sets the result as the session cookie on w. Cookies default to Secure,
+// HttpOnly and SameSite=Lax.
+func Save(w http.ResponseWriter, r *http.Request, values map[string]any) error {
+	mu.RLock()
+	if len(keys) == 0 {
+		mu.RUnlock()
+		return fmt.Errorf("session: no keys configured, call SetKeys first")
+	}
+	key := keys[0]
+	mu.RUnlock()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(values); err != nil {
+		return fmt.Errorf("session: error encoding values: %s", err)
+	}
+
+	ciphertext, err := seal(key, buf.Bytes())
+	if err != nil {
+		return fmt.Errorf("session: error sealing cookie: %s", err)
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     cookieName,
+		Value:    base64.URLEncoding.EncodeToString(ciphertext),
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   true,
+		SameSite: http.SameSiteLaxMode,
+	})
+	return nil
+}
+
+func seal(key, plaintext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return gcm.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+func open(key, ciphertext []byte) ([]byte, error) {
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, fmt.Errorf("session: ciphertext too short")
+	}
+	nonce, sealed := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, sealed, nil)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,74 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestSaveAndGetRoundTrip(t *testing.T) {
+	SetKeys(key("current-key-aaaaaaaaaaaaaaaaaaa"))
+	defer SetKeys()
+
+	rec := httptest.NewRecorder()
+	if err := Save(rec, httptest.NewRequest(http.MethodGet, "/", nil), map[string]any{"user": "alice"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(rec.Result().Cookies()[0])
+
+	values, err := Get(req)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if values["user"] != "alice" {
+		t.Fatalf("expected user=alice, got %v", values["user"])
+	}
+}
+
+func TestKeyRotation(t *testing.T) {
+	oldKey := key("old-key-aaaaaaaaaaaaaaaaaaaaaaa")
+	newKey := key("new-key-bbbbbbbbbbbbbbbbbbbbbbb")
+
+	SetKeys(oldKey)
+	defer SetKeys()
+
+	rec := httptest.NewRecorder()
+	if err := Save(rec, httptest.NewRequest(http.MethodGet, "/", nil), map[string]any{"user": "bob"}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	cookie := rec.Result().Cookies()[0]
+
+	// Rotate in newKey as the encrypting key; oldKey is kept around to
+	// decrypt cookies issued before the rotation.
+	SetKeys(newKey, oldKey)
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	values, err := Get(req)
+	if err != nil {
+		t.Fatalf("Get after rotation: %v", err)
+	}
+	if values["user"] != "bob" {
+		t.Fatalf("expected user=bob after rotation, got %v", values["user"])
+	}
+
+	// Once oldKey is fully retired, a cookie sealed with it should no
+	// longer decrypt.
+	SetKeys(newKey)
+	req = httptest.NewRequest(http.MethodGet, "/", nil)
+	req.AddCookie(cookie)
+	values, err = Get(req)
+	if err != nil {
+		t.Fatalf("Get after retiring old key: %v", err)
+	}
+	if _, ok := values["user"]; ok {
+		t.Fatalf("expected no session value once the sealing key is retired, got %v", values["user"])
+	}
+}
+
+func key(seed string) []byte {
+	k := make([]byte, 32)
+	copy(k, seed)
+	return k
+}
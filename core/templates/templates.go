@@ -1,85 +1,296 @@
+// Package templates loads and renders html/template files to be sent as a
+// Response.
+//
+// Templates are parsed once, up front, into a single *template.Template set
+// via ParseGlob, so rendering never touches disk. Call Reload to re-parse
+// after the files on disk change, or Watch to have that happen automatically
+// whenever a file under the template folder is created, written or removed.
+//
+// . . .
+//
+//	tmpl, err := templates.LoadTemplateFolder("./templates")
+//	tmpl.RegisterFuncMap(template.FuncMap{"upper": strings.ToUpper})
+//	html, err := tmpl.LoadHTML("index.tmpl", map[string]any{"message": "Test"})
+//	html, err := tmpl.LoadHTMLWithLayout("layout.tmpl", "index.tmpl", map[string]any{"message": "Test"})
+//
+// . . .
 package templates
 
 import (
+	"bytes"
 	"fmt"
 	"html/template"
-	"os"
 	"path"
-	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
 )
 
+// bufPool recycles the buffers used to render templates so rendering does
+// not allocate a new strings.Builder/bytes.Buffer on every request.
+var bufPool = sync.Pool{
+	New: func() any { return new(bytes.Buffer) },
+}
+
 // This struct stores template information to be sent as Response.
-// It uses Go http/template to replace variables with maps in JSON compatible format.
+// It uses Go html/template to replace variables with maps in JSON compatible format.
 type Template struct {
-	folder    string
-	templates []string
+	folder  string
+	glob    string
+	funcMap template.FuncMap
+
+	mu      sync.RWMutex
+	tmpl    *template.Template
+	watcher *fsnotify.Watcher
 }
 
-// Loads the template folder, returning a Template instance that stores all the templates information.
-//
-// All template files MUST end with ".tmpl"
+// TemplateOption configures LoadTemplateFolder/LoadTemplateFolderGlob.
+type TemplateOption func(*Template)
+
+// WithFuncMap registers funcMap before the folder's first parse. It's
+// needed for helpers a template body already calls by name - html/template
+// requires every function a template references to be in the FuncMap at
+// parse time, so RegisterFuncMap alone is too late for the very first
+// Reload, which LoadTemplateFolder runs before returning.
+func WithFuncMap(funcMap template.FuncMap) TemplateOption {
+	return func(t *Template) {
+		for name, fn := range funcMap {
+			t.funcMap[name] = fn
+		}
+	}
+}
+
+// Loads the template folder, returning a Template instance with every
+// "*.tmpl" file inside it parsed into a single template set.
 //
 // Usage:
 //
 //	template, err := LoadTemplateFolder("./templates")
-func LoadTemplateFolder(folder string) (*Template, error) {
-	fsFolder, err := os.ReadDir(folder)
-	if err != nil {
-		return nil, fmt.Errorf("error reading from folder: %s", err)
+func LoadTemplateFolder(folder string, opts ...TemplateOption) (*Template, error) {
+	return LoadTemplateFolderGlob(folder, "*.tmpl", opts...)
+}
+
+// LoadTemplateFolderGlob behaves like LoadTemplateFolder but lets the
+// caller pick the glob used to select template files within folder, e.g.
+// "*.html".
+//
+// Usage:
+//
+//	template, err := LoadTemplateFolderGlob("./templates", "*.html")
+func LoadTemplateFolderGlob(folder, glob string, opts ...TemplateOption) (*Template, error) {
+	t := &Template{
+		folder:  folder,
+		glob:    glob,
+		funcMap: template.FuncMap{},
 	}
-	files := []string{}
-	for _, file := range fsFolder {
-		if strings.HasSuffix(file.Name(), ".tmpl") {
-			files = append(files, file.Name())
-		}
+	for _, opt := range opts {
+		opt(t)
+	}
+	if err := t.Reload(); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// RegisterFuncMap attaches helper functions that become available to every
+// template from the next Reload on (including one triggered by Watch). The
+// set already parsed does not see them, so call Reload after registering;
+// helpers a template calls during the first parse need WithFuncMap.
+//
+// Usage:
+//
+//	template.RegisterFuncMap(template.FuncMap{"upper": strings.ToUpper})
+func (t *Template) RegisterFuncMap(funcMap template.FuncMap) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for name, fn := range funcMap {
+		t.funcMap[name] = fn
+	}
+}
+
+// Reload re-reads and re-parses every template file matching the folder's
+// glob. Existing *Template values keep working with the templates parsed
+// before Reload returns an error.
+//
+// Usage:
+//
+//	err := template.Reload()
+func (t *Template) Reload() error {
+	t.mu.RLock()
+	funcMap := make(template.FuncMap, len(t.funcMap))
+	for name, fn := range t.funcMap {
+		funcMap[name] = fn
 	}
-	return &Template{
-		folder:    folder,
-		templates: files,
-	}, nil
+	t.mu.RUnlock()
+
+	tmpl, err := template.New(t.glob).Funcs(funcMap).ParseGlob(path.Join(t.folder, t.glob))
+	if err != nil {
+		return fmt.Errorf("error parsing templates: %s", err)
+	}
+
+	t.mu.Lock()
+	t.tmpl = tmpl
+	t.mu.Unlock()
+	return nil
 }
 
-// Get a template as string, returns error if template not found
+// Watch starts an fsnotify watcher on the template folder and calls Reload
+// whenever a file is created, written or removed. It's meant for dev mode;
+// call Close to stop watching. Errors from a failed Reload and from the
+// watcher are discarded without being logged; a failed Reload leaves the
+// current template set in place, so a malformed in-progress save does not
+// take it down.
 //
 // Usage:
 //
-//	template.GetTemplate("test.tmpl")
-func (t *Template) GetTemplate(filename string) (string, error) {
-	for _, file := range t.templates {
-		if file == filename {
-			c, err := os.ReadFile(path.Join(t.folder, file))
-			if err != nil {
-				return "", err
+//	err := template.Watch()
+//	defer template.Close()
+func (t *Template) Watch() error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("error creating watcher: %s", err)
+	}
+	if err := watcher.Add(t.folder); err != nil {
+		watcher.Close()
+		return fmt.Errorf("error watching folder: %s", err)
+	}
+
+	t.mu.Lock()
+	t.watcher = watcher
+	t.mu.Unlock()
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Remove) != 0 {
+					_ = t.Reload()
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
 			}
-			return string(c), nil
 		}
+	}()
+	return nil
+}
+
+// Close stops the watcher started by Watch. It's a no-op if Watch was
+// never called.
+func (t *Template) Close() error {
+	t.mu.RLock()
+	watcher := t.watcher
+	t.mu.RUnlock()
+	if watcher == nil {
+		return nil
 	}
-	return "", fmt.Errorf("template %s not found", filename)
+	return watcher.Close()
 }
 
-// Loads HTML from template, applying the variable to the template.
-// WARN: This ignores any errors when loading templates.
+// LoadHTML renders the named template with variables, returning an error
+// instead of silently swallowing a missing template or a parse/execute
+// failure.
+//
+// It executes a clone of the parsed set rather than the set itself, since
+// html/template refuses to Clone a template once it has executed -
+// executing the shared t.tmpl directly here would permanently break
+// LoadHTMLWithLayout and RenderWithFuncs for the rest of this parse
+// generation.
 //
 // Usage:
 //
-//	template.LoadHTML("test.tmpl")
-func (t *Template) LoadHTML(name string, variables map[string]any) string {
-	buff := new(strings.Builder)
+//	html, err := template.LoadHTML("test.tmpl", map[string]any{"message": "Test"})
+func (t *Template) LoadHTML(name string, variables map[string]any) (string, error) {
+	t.mu.RLock()
+	tmpl := t.tmpl
+	t.mu.RUnlock()
+	if tmpl == nil {
+		return "", fmt.Errorf("no templates loaded")
+	}
 
-	content, err := t.GetTemplate(name)
+	clone, err := tmpl.Clone()
 	if err != nil {
-		return ""
+		return "", fmt.Errorf("error cloning templates: %s", err)
+	}
+
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufPool.Put(buf)
+
+	if err := clone.ExecuteTemplate(buf, name, variables); err != nil {
+		return "", fmt.Errorf("error executing template %s: %s", name, err)
+	}
+	return buf.String(), nil
+}
+
+// LoadHTMLWithLayout renders page inside layout: it clones the parsed
+// template set, re-parses page on top of the clone so its
+// {{define "content"}} block wins, and executes layout against that clone.
+// This lets several pages share the same layout while each defining its own
+// "content" block without their blocks clobbering one another in the base
+// set.
+//
+// Usage:
+//
+//	html, err := template.LoadHTMLWithLayout("layout.tmpl", "index.tmpl", map[string]any{"message": "Test"})
+func (t *Template) LoadHTMLWithLayout(layout, page string, variables map[string]any) (string, error) {
+	t.mu.RLock()
+	tmpl := t.tmpl
+	t.mu.RUnlock()
+	if tmpl == nil {
+		return "", fmt.Errorf("no templates loaded")
 	}
 
-	tmpl, err := template.New("template").Parse(content)
+	clone, err := tmpl.Clone()
 	if err != nil {
-		return ""
+		return "", fmt.Errorf("error cloning templates: %s", err)
 	}
+	if _, err := clone.ParseFiles(path.Join(t.folder, page)); err != nil {
+		return "", fmt.Errorf("error parsing page %s: %s", page, err)
+	}
+
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufPool.Put(buf)
 
-	err = tmpl.Execute(buff, variables)
+	if err := clone.ExecuteTemplate(buf, layout, variables); err != nil {
+		return "", fmt.Errorf("error executing layout %s: %s", layout, err)
+	}
+	return buf.String(), nil
+}
+
+// RenderWithFuncs behaves like LoadHTML but first clones the template set
+// and attaches funcMap to the clone. It exists for helpers that need
+// per-request state, such as a CSRF token, which can't be registered once
+// on the shared set the way RegisterFuncMap's helpers are.
+//
+// Usage:
+//
+//	html, err := template.RenderWithFuncs("form.tmpl", vars, csrf.FuncMap(r))
+func (t *Template) RenderWithFuncs(name string, variables map[string]any, funcMap template.FuncMap) (string, error) {
+	t.mu.RLock()
+	tmpl := t.tmpl
+	t.mu.RUnlock()
+	if tmpl == nil {
+		return "", fmt.Errorf("no templates loaded")
+	}
+
+	clone, err := tmpl.Clone()
 	if err != nil {
-		return ""
+		return "", fmt.Errorf("error cloning templates: %s", err)
 	}
+	clone = clone.Funcs(funcMap)
 
-	return buff.String()
+	buf := bufPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	defer bufPool.Put(buf)
+
+	if err := clone.ExecuteTemplate(buf, name, variables); err != nil {
+		return "", fmt.Errorf("error executing template %s: %s", name, err)
+	}
+	return buf.String(), nil
 }